@@ -0,0 +1,319 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/yashschandra/upscayl-cli/upscayl"
+)
+
+// manifestEntry records the outcome of upscayling a single file in a
+// batch run, written to manifest.json so an interrupted batch can be
+// inspected or resumed.
+type manifestEntry struct {
+	Input     string `json:"input"`
+	Output    string `json:"output"`
+	Sha256In  string `json:"sha256_in"`
+	Sha256Out string `json:"sha256_out,omitempty"`
+	BytesIn   int64  `json:"bytes_in"`
+	BytesOut  int64  `json:"bytes_out,omitempty"`
+	ElapsedMs int64  `json:"elapsed_ms"`
+	Model     string `json:"model"`
+	Scale     string `json:"scale"`
+	Error     string `json:"error,omitempty"`
+}
+
+// batchOptions holds the flags governing a directory run.
+type batchOptions struct {
+	recursive    bool
+	include      string
+	exclude      string
+	workers      int
+	skipExisting bool
+	onError      string
+	resumePath   string
+	maxGPUJobs   int
+	gpuIDs       []int
+}
+
+func parseGPUIDs(spec string) []int {
+	if spec == "" || spec == "auto" {
+		return nil
+	}
+	var ids []int
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		var id int
+		if _, err := fmt.Sscanf(part, "%d", &id); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// walkInputFiles collects every file under root matching include/exclude
+// glob patterns (matched against the base name), optionally recursing
+// into subdirectories.
+func walkInputFiles(root string, opts batchOptions) ([]string, error) {
+	var files []string
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		path := filepath.Join(root, entry.Name())
+		if entry.IsDir() {
+			if opts.recursive {
+				sub, err := walkInputFiles(path, opts)
+				if err != nil {
+					return nil, err
+				}
+				files = append(files, sub...)
+			}
+			continue
+		}
+		if !matchesFilters(entry.Name(), opts.include, opts.exclude) {
+			continue
+		}
+		files = append(files, path)
+	}
+	return files, nil
+}
+
+func matchesFilters(name, include, exclude string) bool {
+	if include != "" && !matchesAny(name, include) {
+		return false
+	}
+	if exclude != "" && matchesAny(name, exclude) {
+		return false
+	}
+	return true
+}
+
+func matchesAny(name, patterns string) bool {
+	for _, pattern := range strings.Split(patterns, ",") {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func loadResumeManifest(path string) (map[string]bool, error) {
+	done := make(map[string]bool)
+	if path == "" {
+		return done, nil
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return done, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entries []manifestEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		if e.Error == "" {
+			done[e.Input] = true
+		}
+	}
+	return done, nil
+}
+
+func sha256File(path string) (string, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(h.Sum(nil)), n, nil
+}
+
+// runBatch upscayls every file under inputDir into a mirrored tree under
+// outputDir using a pool of opts.workers goroutines, writing
+// manifest.json as it goes.
+func runBatch(cmd *cobra.Command, inputDir, outputDir string, base upscayl.Input, opts batchOptions) error {
+	files, err := walkInputFiles(inputDir, opts)
+	if err != nil {
+		return fmt.Errorf("walking %s: %w", inputDir, err)
+	}
+	if len(files) == 0 {
+		log.Println("no input files matched under", inputDir)
+		return nil
+	}
+
+	alreadyDone, err := loadResumeManifest(opts.resumePath)
+	if err != nil {
+		return fmt.Errorf("loading resume manifest: %w", err)
+	}
+
+	if err := os.MkdirAll(outputDir, 0o755); err != nil {
+		return err
+	}
+
+	var gpuSem []chan struct{}
+	for range opts.gpuIDs {
+		gpuSem = append(gpuSem, make(chan struct{}, opts.maxGPUJobs))
+	}
+
+	jobs := make(chan string)
+	results := make(chan manifestEntry)
+	stop := make(chan struct{})
+	var stopOnce sync.Once
+
+	var wg sync.WaitGroup
+	for i := 0; i < opts.workers; i++ {
+		wg.Add(1)
+		workerID := i
+		go func() {
+			defer wg.Done()
+			for path := range jobs {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+
+				entry := processOneFile(path, inputDir, outputDir, base, opts, workerID, gpuSem)
+				results <- entry
+				if entry.Error != "" && opts.onError == "stop" {
+					stopOnce.Do(func() { close(stop) })
+				}
+			}
+		}()
+	}
+
+	go func() {
+		defer close(jobs)
+		for _, f := range files {
+			if alreadyDone[f] {
+				continue
+			}
+			select {
+			case jobs <- f:
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	var manifest []manifestEntry
+	var completed int64
+	total := len(files)
+	start := time.Now()
+	for entry := range results {
+		manifest = append(manifest, entry)
+		n := atomic.AddInt64(&completed, 1)
+		elapsed := time.Since(start)
+		throughput := float64(n) / elapsed.Seconds()
+		var eta time.Duration
+		if throughput > 0 {
+			eta = time.Duration(float64(total-int(n))/throughput) * time.Second
+		}
+		log.Printf("[%d/%d] %s ok=%v elapsed=%s eta=%s", n, total, entry.Input, entry.Error == "", elapsed.Round(time.Second), eta.Round(time.Second))
+	}
+
+	manifestPath := filepath.Join(outputDir, "manifest.json")
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(manifestPath, data, 0o644); err != nil {
+		return err
+	}
+	log.Println("wrote manifest to", manifestPath)
+	return nil
+}
+
+// processOneFile upscayls a single file, optionally pinning the job to a
+// GPU id semaphore, and returns its manifest entry.
+func processOneFile(path, inputDir, outputDir string, base upscayl.Input, opts batchOptions, workerID int, gpuSem []chan struct{}) manifestEntry {
+	rel, err := filepath.Rel(inputDir, path)
+	if err != nil {
+		rel = filepath.Base(path)
+	}
+	outPath := filepath.Join(outputDir, rel)
+	entry := manifestEntry{Input: path, Output: outPath, Model: base.Model, Scale: base.Scale}
+
+	if opts.skipExisting {
+		if _, err := os.Stat(outPath); err == nil {
+			entry.Error = ""
+			return entry
+		}
+	}
+
+	if sum, size, err := sha256File(path); err == nil {
+		entry.Sha256In = sum
+		entry.BytesIn = size
+	}
+
+	if err := os.MkdirAll(filepath.Dir(outPath), 0o755); err != nil {
+		entry.Error = err.Error()
+		return entry
+	}
+
+	var sem chan struct{}
+	if len(gpuSem) > 0 {
+		sem = gpuSem[workerID%len(gpuSem)]
+		sem <- struct{}{}
+		defer func() { <-sem }()
+	}
+
+	input := base
+	input.ImagePath = path
+	input.OutputPath = outPath
+	if len(opts.gpuIDs) > 0 {
+		gpuID := opts.gpuIDs[workerID%len(opts.gpuIDs)]
+		input.GPUId = &gpuID
+	}
+
+	start := time.Now()
+	resultPath, err := upscayl.Upscayl(input)
+	entry.ElapsedMs = time.Since(start).Milliseconds()
+	if err != nil {
+		entry.Error = err.Error()
+		if opts.onError == "stop" {
+			log.Println("error upscayling", path, "- stopping:", err.Error())
+		} else {
+			log.Println("error upscayling", path, "- continuing:", err.Error())
+		}
+		return entry
+	}
+
+	if sum, size, err := sha256File(resultPath); err == nil {
+		entry.Sha256Out = sum
+		entry.BytesOut = size
+	}
+	return entry
+}
@@ -0,0 +1,136 @@
+// Package optimize runs upscayl's output images through external,
+// format-specific lossless/lossy optimizers (jpegoptim, optipng, pngquant,
+// gifsicle) to shrink the typically oversized PNGs the GAN models
+// produce.
+package optimize
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// Optimizer runs one external binary over an image file in place.
+type Optimizer struct {
+	// Format is the lowercase file extension this optimizer handles,
+	// without the leading dot (e.g. "png", "jpg").
+	Format string
+	// Bin is the executable name or path used to invoke the optimizer.
+	Bin string
+	// Args builds the argument list for a given file path and compression
+	// quality (0 means "use the optimizer's default").
+	Args func(path string, quality int) []string
+}
+
+// DefaultOptimizers is the built-in chain, keyed by format. Multiple
+// optimizers can run against the same format (e.g. png gets both optipng
+// and pngquant), and they run in the order listed here.
+func DefaultOptimizers(paths map[string]string) []Optimizer {
+	bin := func(name string) string {
+		if p, ok := paths[name]; ok && p != "" {
+			return p
+		}
+		return name
+	}
+
+	return []Optimizer{
+		{
+			Format: "jpg",
+			Bin:    bin("jpegoptim"),
+			Args: func(path string, quality int) []string {
+				args := []string{"--strip-none"}
+				if quality > 0 {
+					args = append(args, "-m"+strconv.Itoa(quality))
+				}
+				return append(args, path)
+			},
+		},
+		{
+			Format: "png",
+			Bin:    bin("optipng"),
+			Args: func(path string, quality int) []string {
+				return []string{"-o2", path}
+			},
+		},
+		{
+			Format: "png",
+			Bin:    bin("pngquant"),
+			Args: func(path string, quality int) []string {
+				return []string{"--force", "--ext", ".png", "--skip-if-larger", path}
+			},
+		},
+		{
+			Format: "gif",
+			Bin:    bin("gifsicle"),
+			Args: func(path string, quality int) []string {
+				return []string{"-O3", "--batch", path}
+			},
+		},
+		// No webp entry: cwebp only decodes PNG/JPEG/TIFF, so it can't
+		// take an already-produced .webp file as input to re-optimize it.
+	}
+}
+
+// Installed reports which of the given optimizer binaries are present on
+// PATH, keyed by binary name.
+func Installed(optimizers []Optimizer) map[string]bool {
+	seen := make(map[string]bool)
+	status := make(map[string]bool)
+	for _, o := range optimizers {
+		if seen[o.Bin] {
+			continue
+		}
+		seen[o.Bin] = true
+		_, err := exec.LookPath(o.Bin)
+		status[o.Bin] = err == nil
+	}
+	return status
+}
+
+// Run applies every optimizer registered for path's format, in order,
+// skipping any optimizer whose binary isn't installed rather than failing
+// the whole chain.
+func Run(path string, quality int, optimizers []Optimizer) error {
+	format := strings.TrimPrefix(strings.ToLower(ext(path)), ".")
+	if format == "jpeg" {
+		format = "jpg"
+	}
+
+	for _, o := range optimizers {
+		if o.Format != format {
+			continue
+		}
+		if _, err := exec.LookPath(o.Bin); err != nil {
+			continue
+		}
+		cmd := exec.Command(o.Bin, o.Args(path, quality)...)
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("running %s on %s: %w", o.Bin, path, err)
+		}
+	}
+	return nil
+}
+
+// CopyEXIF copies EXIF metadata from src into dst using exiftool, when
+// exiftool is available. It is a no-op (returning nil) if exiftool isn't
+// installed, since EXIF preservation is best-effort.
+func CopyEXIF(src, dst string) error {
+	if _, err := exec.LookPath("exiftool"); err != nil {
+		return nil
+	}
+	cmd := exec.Command("exiftool", "-tagsFromFile", src, "-overwrite_original", dst)
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+func ext(path string) string {
+	for i := len(path) - 1; i >= 0 && path[i] != '/'; i-- {
+		if path[i] == '.' {
+			return path[i:]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"image"
+	"image/jpeg"
+	"image/png"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/yashschandra/upscayl-cli/upscayl/preproc"
+)
+
+// runPreproc decodes inputPath, runs it through the steps described by
+// spec, and writes the result to outputPath.
+func runPreproc(spec, inputPath, outputPath string, opts preproc.Options) error {
+	steps, err := preproc.Parse(spec, opts)
+	if err != nil {
+		return err
+	}
+	if len(steps) == 0 {
+		return nil
+	}
+
+	f, err := os.Open(inputPath)
+	if err != nil {
+		return err
+	}
+	img, _, err := image.Decode(f)
+	f.Close()
+	if err != nil {
+		return err
+	}
+
+	result := preproc.Run(steps, img)
+
+	out, err := os.Create(outputPath)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	if strings.HasSuffix(strings.ToLower(outputPath), ".jpg") || strings.HasSuffix(strings.ToLower(outputPath), ".jpeg") {
+		return jpeg.Encode(out, result, &jpeg.Options{Quality: 95})
+	}
+	return png.Encode(out, result)
+}
+
+func getPreprocCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "preproc",
+		Short: "Run classical scan-cleanup steps (deskew, denoise, binarize, wipe-borders) on an image",
+		Run: func(cmd *cobra.Command, args []string) {
+			input, _ := cmd.Flags().GetString("input")
+			output, _ := cmd.Flags().GetString("output")
+			steps, _ := cmd.Flags().GetString("preproc")
+			sauvolaWindow, _ := cmd.Flags().GetInt("sauvola-window")
+			wipeThreshold, _ := cmd.Flags().GetFloat64("wipe-threshold")
+
+			opts := preproc.Options{SauvolaWindow: sauvolaWindow, WipeThreshold: wipeThreshold}
+			if err := runPreproc(steps, input, output, opts); err != nil {
+				log.Fatal("error while preprocessing: ", err.Error())
+			}
+			log.Println("preprocessed output at", output)
+		},
+	}
+	cmd.Flags().StringP("input", "i", "", "Input image path")
+	cmd.Flags().StringP("output", "o", "", "Output image path")
+	cmd.Flags().String("preproc", "deskew,denoise,binarize,wipe-borders", "Comma-separated preprocessing steps to run, in order")
+	cmd.Flags().Int("sauvola-window", 19, "Sauvola binarization window size")
+	cmd.Flags().Float64("wipe-threshold", 0.95, "Black-pixel ratio above which a border row/column is wiped")
+	return cmd
+}
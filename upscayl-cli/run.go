@@ -2,10 +2,23 @@ package main
 
 import (
 	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
 	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 
 	"github.com/spf13/cobra"
+	"github.com/yashschandra/upscayl-cli/optimize"
 	"github.com/yashschandra/upscayl-cli/upscayl"
+	"github.com/yashschandra/upscayl-cli/upscayl/filters"
+	"github.com/yashschandra/upscayl-cli/upscayl/preproc"
+	"github.com/yashschandra/upscayl-cli/upscayl/thumbnail"
 )
 
 func getRunCommand() *cobra.Command {
@@ -13,6 +26,11 @@ func getRunCommand() *cobra.Command {
 		Use:   "run",
 		Short: "Upscayl single image using command line options",
 		Run: func(cmd *cobra.Command, args []string) {
+			if listOptimizers, _ := cmd.Flags().GetBool("list-optimizers"); listOptimizers {
+				printInstalledOptimizers(cmd)
+				return
+			}
+
 			image, _ := cmd.Flags().GetString("input")
 			url, _ := cmd.Flags().GetString("url")
 			model, _ := cmd.Flags().GetString("model-name")
@@ -27,6 +45,35 @@ func getRunCommand() *cobra.Command {
 			verbose, _ := cmd.Flags().GetBool("verbose")
 			_ = verbose
 
+			if info, err := os.Stat(image); err == nil && info.IsDir() {
+				runBatchFromFlags(cmd, image, output, model, modelPath, format, scale, compress, tta)
+				return
+			}
+
+			// Captured before --preproc may reassign image to a scratch
+			// file: runPreproc re-encodes via the stdlib jpeg/png
+			// encoders, which drop EXIF, so orientation must be read from
+			// the original source.
+			origImage := image
+
+			if preprocSteps, _ := cmd.Flags().GetString("preproc"); preprocSteps != "" {
+				sauvolaWindow, _ := cmd.Flags().GetInt("sauvola-window")
+				wipeThreshold, _ := cmd.Flags().GetFloat64("wipe-threshold")
+				opts := preproc.Options{SauvolaWindow: sauvolaWindow, WipeThreshold: wipeThreshold}
+
+				preprocOut, err := os.CreateTemp("", "upscayl-preproc-*"+filepath.Ext(image))
+				if err != nil {
+					log.Fatal("error while preprocessing: ", err.Error())
+				}
+				preprocOut.Close()
+				defer os.Remove(preprocOut.Name())
+
+				if err := runPreproc(preprocSteps, image, preprocOut.Name(), opts); err != nil {
+					log.Fatal("error while preprocessing: ", err.Error())
+				}
+				image = preprocOut.Name()
+			}
+
 			input := upscayl.Input{
 				ImagePath:  image,
 				ImageURL:   url,
@@ -65,6 +112,22 @@ func getRunCommand() *cobra.Command {
 				log.Fatal("error while upscayling", err.Error())
 			}
 			log.Println("output image at", outputPath)
+
+			if filterSpecs, _ := cmd.Flags().GetStringArray("filter"); len(filterSpecs) > 0 {
+				if err := applyFilters(filterSpecs, outputPath, format); err != nil {
+					log.Fatal("error while applying filters: ", err.Error())
+				}
+			}
+
+			if err := resizeAndThumbnail(cmd, origImage, outputPath); err != nil {
+				log.Fatal("error while resizing output: ", err.Error())
+			}
+
+			if optimizeOutput, _ := cmd.Flags().GetBool("optimize"); optimizeOutput {
+				if err := optimizeAndPreserve(cmd, origImage, outputPath, compress); err != nil {
+					log.Fatal("error while optimizing output: ", err.Error())
+				}
+			}
 		},
 	}
 	cmd.Flags().StringP("input", "i", "", "Input image path (jpg/png/webp) or directory")
@@ -83,5 +146,283 @@ func getRunCommand() *cobra.Command {
 	cmd.Flags().BoolP("tta", "x", false, "Enable TTA mode")
 	cmd.Flags().StringP("format", "f", "ext/png", "Output image format (jpg/png/webp)")
 	cmd.Flags().BoolP("verbose", "v", false, "Verbose output")
+	cmd.Flags().Bool("optimize", false, "Run the output image through the external optimizer chain (jpegoptim/optipng/pngquant/gifsicle)")
+	cmd.Flags().String("optimizer-path", "", "Comma-separated overrides for optimizer binary paths, e.g. jpegoptim=/usr/bin/jpegoptim,optipng=/opt/bin/optipng")
+	cmd.Flags().Bool("preserve-mtime", false, "Preserve the input file's modification time on the optimized output")
+	cmd.Flags().Bool("preserve-exif", false, "Copy EXIF metadata from the input into the optimized output (requires exiftool)")
+	cmd.Flags().Bool("list-optimizers", false, "List which optimizer binaries are installed and exit")
+	cmd.Flags().StringArray("filter", nil, "Post-upscayl image filter to apply, repeatable and applied in order, e.g. --filter grayscale --filter \"gaussian-blur:3\"")
+	cmd.Flags().Bool("recursive", false, "Recurse into subdirectories when --input is a directory")
+	cmd.Flags().String("include", "", "Comma-separated glob patterns of files to include when --input is a directory, e.g. *.jpg,*.png")
+	cmd.Flags().String("exclude", "", "Comma-separated glob patterns of files to exclude when --input is a directory")
+	cmd.Flags().Int("workers", 1, "Number of concurrent upscayl workers when --input is a directory")
+	cmd.Flags().Bool("skip-existing", false, "Skip files whose output already exists when --input is a directory")
+	cmd.Flags().String("on-error", "continue", "What to do when a file fails during a directory run: continue or stop")
+	cmd.Flags().String("resume", "", "Path to a manifest.json from a previous directory run to resume from")
+	cmd.Flags().Int("max-gpu-jobs", 1, "Maximum concurrent jobs per GPU id when --input is a directory")
+	cmd.Flags().String("preproc", "", "Comma-separated scan-cleanup steps to run on the input before upscayling, e.g. deskew,denoise,binarize,wipe-borders")
+	cmd.Flags().Int("sauvola-window", 19, "Sauvola binarization window size, used by --preproc binarize")
+	cmd.Flags().Float64("wipe-threshold", 0.95, "Black-pixel ratio above which a border row/column is wiped, used by --preproc wipe-borders")
+	cmd.Flags().String("background", "", "Background color (e.g. \"#ffffff\") used to letterbox a --resize fit when the target aspect differs")
+	cmd.Flags().String("thumbnail-sizes", "", "Comma-separated list of additional thumbnail sizes to emit, e.g. 256,512,1024, written as <base>@<size>.<ext>")
 	return cmd
 }
+
+// resizeAndThumbnail wires --resize/--width end-to-end: it EXIF-corrects
+// the output against the original input's orientation, resizes it per
+// the requested geometry, and emits any requested --thumbnail-sizes
+// derivatives.
+func resizeAndThumbnail(cmd *cobra.Command, inputPath, outputPath string) error {
+	resizeSpec, _ := cmd.Flags().GetString("resize")
+	width, _ := cmd.Flags().GetInt("width")
+	backgroundSpec, _ := cmd.Flags().GetString("background")
+	thumbnailSizes, _ := cmd.Flags().GetString("thumbnail-sizes")
+
+	if resizeSpec == "default" && width == 0 && thumbnailSizes == "" {
+		return nil
+	}
+	if resizeSpec == "default" && width > 0 {
+		resizeSpec = fmt.Sprintf("%dx", width)
+	}
+
+	geometry, err := thumbnail.Parse(resizeSpec)
+	if err != nil {
+		return err
+	}
+
+	background, err := parseBackground(backgroundSpec)
+	if err != nil {
+		return err
+	}
+
+	orientation := thumbnail.ReadOrientation(inputPath)
+
+	f, err := os.Open(outputPath)
+	if err != nil {
+		return err
+	}
+	img, _, err := image.Decode(f)
+	f.Close()
+	if err != nil {
+		return fmt.Errorf("decoding %s: %w", outputPath, err)
+	}
+	img = thumbnail.CorrectOrientation(img, orientation)
+
+	if geometry.Mode != 0 || geometry.Width != 0 || geometry.Height != 0 {
+		resized := thumbnail.Resize(img, geometry, background)
+		if err := encodeImage(outputPath, resized); err != nil {
+			return err
+		}
+		img = resized
+	} else if err := encodeImage(outputPath, img); err != nil {
+		return err
+	}
+
+	for _, sizeStr := range strings.Split(thumbnailSizes, ",") {
+		sizeStr = strings.TrimSpace(sizeStr)
+		if sizeStr == "" {
+			continue
+		}
+		size, err := strconv.Atoi(sizeStr)
+		if err != nil {
+			return fmt.Errorf("--thumbnail-sizes: invalid size %q", sizeStr)
+		}
+		derivative := thumbnail.Resize(img, thumbnail.Geometry{Mode: thumbnail.ModeThumbSquare, Width: size, Height: size}, background)
+		derivativePath := thumbnail.DerivativeName(outputPath, size)
+		if err := encodeImage(derivativePath, derivative); err != nil {
+			return err
+		}
+		log.Println("thumbnail derivative at", derivativePath)
+	}
+
+	return nil
+}
+
+// parseBackground parses a "#rrggbb" color spec, returning nil when spec
+// is empty (no letterboxing requested).
+func parseBackground(spec string) (color.Color, error) {
+	if spec == "" {
+		return nil, nil
+	}
+	spec = strings.TrimPrefix(spec, "#")
+	if len(spec) != 6 {
+		return nil, fmt.Errorf("--background: expected #rrggbb, got %q", spec)
+	}
+	r, err1 := strconv.ParseUint(spec[0:2], 16, 8)
+	g, err2 := strconv.ParseUint(spec[2:4], 16, 8)
+	b, err3 := strconv.ParseUint(spec[4:6], 16, 8)
+	if err1 != nil || err2 != nil || err3 != nil {
+		return nil, fmt.Errorf("--background: invalid hex color %q", spec)
+	}
+	return color.RGBA{uint8(r), uint8(g), uint8(b), 255}, nil
+}
+
+// encodeImage writes img to path in a format inferred from its
+// extension, defaulting to PNG.
+func encodeImage(path string, img image.Image) error {
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	ext := strings.ToLower(path)
+	switch {
+	case strings.HasSuffix(ext, ".jpg"), strings.HasSuffix(ext, ".jpeg"):
+		return jpeg.Encode(out, img, &jpeg.Options{Quality: 95})
+	case strings.HasSuffix(ext, ".gif"):
+		return gif.Encode(out, img, nil)
+	default:
+		return png.Encode(out, img)
+	}
+}
+
+// runBatchFromFlags reads the directory-mode flags off cmd and dispatches
+// to runBatch.
+func runBatchFromFlags(cmd *cobra.Command, imageDir, output, model, modelPath, format string, scale, compress int, tta bool) {
+	recursive, _ := cmd.Flags().GetBool("recursive")
+	include, _ := cmd.Flags().GetString("include")
+	exclude, _ := cmd.Flags().GetString("exclude")
+	workers, _ := cmd.Flags().GetInt("workers")
+	skipExisting, _ := cmd.Flags().GetBool("skip-existing")
+	onError, _ := cmd.Flags().GetString("on-error")
+	resume, _ := cmd.Flags().GetString("resume")
+	maxGPUJobs, _ := cmd.Flags().GetInt("max-gpu-jobs")
+	gpuId, _ := cmd.Flags().GetString("gpu-id")
+
+	if workers < 1 {
+		workers = 1
+	}
+	if output == "" {
+		log.Fatal("--output is required when --input is a directory")
+	}
+
+	base := upscayl.Input{Model: model, TTAMode: tta}
+	if cmd.Flags().Changed("format") {
+		base.SaveImageAs = format
+	}
+	if cmd.Flags().Changed("output-scale") {
+		base.Scale = fmt.Sprintf("%d", scale)
+	}
+	if cmd.Flags().Changed("compress") {
+		base.Compression = fmt.Sprintf("%d", compress)
+	}
+	if cmd.Flags().Changed("model-path") {
+		base.ModelPath = modelPath
+	}
+
+	opts := batchOptions{
+		recursive:    recursive,
+		include:      include,
+		exclude:      exclude,
+		workers:      workers,
+		skipExisting: skipExisting,
+		onError:      onError,
+		resumePath:   resume,
+		maxGPUJobs:   maxGPUJobs,
+		gpuIDs:       parseGPUIDs(gpuId),
+	}
+
+	if err := runBatch(cmd, imageDir, output, base, opts); err != nil {
+		log.Fatal("error during batch run: ", err.Error())
+	}
+}
+
+// applyFilters decodes the file at path, runs it through the requested
+// filter pipeline, and re-encodes it in place using the given output
+// format.
+func applyFilters(specs []string, path, format string) error {
+	pipeline, err := filters.ParsePipeline(specs)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	img, _, err := image.Decode(f)
+	f.Close()
+	if err != nil {
+		return fmt.Errorf("decoding %s: %w", path, err)
+	}
+
+	filtered := pipeline.Run(img)
+
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	switch strings.TrimPrefix(strings.ToLower(format), "ext/") {
+	case "jpg", "jpeg":
+		return jpeg.Encode(out, filtered, &jpeg.Options{Quality: 95})
+	case "gif":
+		return gif.Encode(out, filtered, nil)
+	default:
+		return png.Encode(out, filtered)
+	}
+}
+
+// parseOptimizerPaths turns "jpegoptim=/usr/bin/jpegoptim,optipng=/x/optipng"
+// into a name->path map consumed by optimize.DefaultOptimizers.
+func parseOptimizerPaths(spec string) map[string]string {
+	paths := make(map[string]string)
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		paths[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+	return paths
+}
+
+func printInstalledOptimizers(cmd *cobra.Command) {
+	optimizerPath, _ := cmd.Flags().GetString("optimizer-path")
+	optimizers := optimize.DefaultOptimizers(parseOptimizerPaths(optimizerPath))
+	for bin, ok := range optimize.Installed(optimizers) {
+		status := "not found"
+		if ok {
+			status = "found"
+		}
+		log.Printf("%-12s %s", bin, status)
+	}
+}
+
+// optimizeAndPreserve runs the external optimizer chain against
+// outputPath and, if requested, preserves the original file's mtime and
+// EXIF metadata.
+func optimizeAndPreserve(cmd *cobra.Command, inputPath, outputPath string, quality int) error {
+	optimizerPath, _ := cmd.Flags().GetString("optimizer-path")
+	preserveMtime, _ := cmd.Flags().GetBool("preserve-mtime")
+	preserveExif, _ := cmd.Flags().GetBool("preserve-exif")
+
+	optimizers := optimize.DefaultOptimizers(parseOptimizerPaths(optimizerPath))
+	if err := optimize.Run(outputPath, quality, optimizers); err != nil {
+		return err
+	}
+
+	if preserveExif {
+		if err := optimize.CopyEXIF(inputPath, outputPath); err != nil {
+			log.Println("warning: could not copy EXIF metadata:", err.Error())
+		}
+	}
+
+	if preserveMtime {
+		if info, err := os.Stat(inputPath); err == nil {
+			mtime := info.ModTime()
+			if err := os.Chtimes(outputPath, mtime, mtime); err != nil {
+				log.Println("warning: could not preserve mtime:", err.Error())
+			}
+		}
+	}
+
+	log.Println("optimized output at", outputPath)
+	return nil
+}
@@ -0,0 +1,340 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/spf13/cobra"
+	"github.com/yashschandra/upscayl-cli/upscayl"
+)
+
+// errServerBusy is returned by upscaylFile when the worker pool has no
+// free slot.
+var errServerBusy = errors.New("server busy")
+
+var (
+	jobDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "upscayl_job_duration_seconds",
+		Help:    "Time taken to upscayl a single image, in seconds.",
+		Buckets: prometheus.DefBuckets,
+	})
+	jobFailures = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "upscayl_job_failures_total",
+		Help: "Total number of upscayl jobs that returned an error.",
+	})
+	jobsInFlight = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "upscayl_jobs_in_flight",
+		Help: "Number of upscayl jobs currently running.",
+	})
+)
+
+// jobStatus tracks an async job requested via /jobs.
+type jobStatus struct {
+	ID         string `json:"id"`
+	Done       bool   `json:"done"`
+	Error      string `json:"error,omitempty"`
+	OutputPath string `json:"output_path,omitempty"`
+}
+
+// server holds the state shared across HTTP handlers: a semaphore bounding
+// concurrent native upscayl processes, and a map of in-flight/completed
+// async jobs.
+type server struct {
+	sem       chan struct{}
+	model     string
+	modelPath string
+	jobsMu    sync.Mutex
+	jobs      map[string]*jobStatus
+}
+
+func newServer(maxConcurrent int, model, modelPath string) *server {
+	return &server{
+		sem:       make(chan struct{}, maxConcurrent),
+		model:     model,
+		modelPath: modelPath,
+		jobs:      make(map[string]*jobStatus),
+	}
+}
+
+func (s *server) acquire(ctx context.Context) (bool, func()) {
+	select {
+	case s.sem <- struct{}{}:
+		return true, func() { <-s.sem }
+	default:
+	}
+	select {
+	case s.sem <- struct{}{}:
+		return true, func() { <-s.sem }
+	case <-ctx.Done():
+		return false, func() {}
+	}
+}
+
+// upscaylFile acquires a worker-pool slot and runs upscayl.Upscayl in the
+// background, returning errServerBusy immediately if the pool is full.
+// cleanupInput is called once the native call has actually finished
+// (success, failure, or the caller having already given up on ctx),
+// never before — so callers must not remove imagePath themselves.
+// If ctx is cancelled before the native call finishes, upscaylFile
+// returns early with ctx.Err(), but the background goroutine keeps
+// running to completion and only then releases the slot and decrements
+// jobsInFlight — so a stream of cancelled/timed-out requests can't let
+// more than maxConcurrent native processes run at once. In that case
+// nobody is left to consume a successful outputPath, so upscaylFile
+// removes it itself once the background call lands.
+func (s *server) upscaylFile(ctx context.Context, imagePath, outputPath string, cleanupInput func()) (string, error) {
+	ok, release := s.acquire(ctx)
+	if !ok {
+		cleanupInput()
+		return "", errServerBusy
+	}
+
+	type result struct {
+		path string
+		err  error
+	}
+	resultCh := make(chan result, 1)
+
+	jobsInFlight.Inc()
+	start := time.Now()
+	go func() {
+		defer jobsInFlight.Dec()
+		defer release()
+		defer cleanupInput()
+
+		outPath, err := upscayl.Upscayl(upscayl.Input{
+			ImagePath:  imagePath,
+			Model:      s.model,
+			ModelPath:  s.modelPath,
+			OutputPath: outputPath,
+		})
+
+		jobDuration.Observe(time.Since(start).Seconds())
+		if err != nil {
+			jobFailures.Inc()
+		}
+		resultCh <- result{outPath, err}
+	}()
+
+	select {
+	case r := <-resultCh:
+		return r.path, r.err
+	case <-ctx.Done():
+		// The caller is about to return; it will never read resultCh, so
+		// the output file (if the native call goes on to succeed) would
+		// otherwise leak on disk forever. Wait for it in the background
+		// and remove it ourselves.
+		go func() {
+			if r := <-resultCh; r.err == nil {
+				os.Remove(r.path)
+			}
+		}()
+		return "", ctx.Err()
+	}
+}
+
+func (s *server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("ok"))
+}
+
+func (s *server) handleUpscale(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	tmpIn, cleanup, err := readUpscaleRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	tmpOut := tmpIn + "_out.png"
+	outputPath, err := s.upscaylFile(r.Context(), tmpIn, tmpOut, cleanup)
+	if errors.Is(err, errServerBusy) {
+		w.Header().Set("Retry-After", "2")
+		http.Error(w, "server busy, try again shortly", http.StatusTooManyRequests)
+		return
+	}
+	if err != nil {
+		http.Error(w, "upscayl failed: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer os.Remove(outputPath)
+
+	data, err := os.ReadFile(outputPath)
+	if err != nil {
+		http.Error(w, "could not read upscayled output", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Write(data)
+}
+
+// readUpscaleRequest accepts either a multipart/form-data upload under the
+// "image" field or a JSON body of the form {"url": "..."}, and returns the
+// path to a temporary file holding the source image. The returned cleanup
+// func closes tmp (a second, post-success Close is harmless) and removes
+// it; callers must invoke it exactly once the file is no longer needed.
+func readUpscaleRequest(r *http.Request) (path string, cleanup func(), err error) {
+	tmp, err := os.CreateTemp("", "upscayl-serve-*")
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup = func() { tmp.Close(); os.Remove(tmp.Name()) }
+
+	contentType := r.Header.Get("Content-Type")
+	if len(contentType) >= 19 && contentType[:19] == "multipart/form-data" {
+		file, _, ferr := r.FormFile("image")
+		if ferr != nil {
+			cleanup()
+			return "", nil, fmt.Errorf("missing \"image\" form file: %w", ferr)
+		}
+		defer file.Close()
+		if _, err := io.Copy(tmp, file); err != nil {
+			cleanup()
+			return "", nil, err
+		}
+		if err := tmp.Close(); err != nil {
+			os.Remove(tmp.Name())
+			return "", nil, err
+		}
+		return tmp.Name(), cleanup, nil
+	}
+
+	var body struct {
+		URL string `json:"url"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.URL == "" {
+		cleanup()
+		return "", nil, fmt.Errorf("expected multipart \"image\" field or JSON body with \"url\"")
+	}
+
+	resp, err := http.Get(body.URL)
+	if err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("fetching url: %w", err)
+	}
+	defer resp.Body.Close()
+	if _, err := io.Copy(tmp, resp.Body); err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return "", nil, err
+	}
+	return tmp.Name(), cleanup, nil
+}
+
+func (s *server) handleSubmitJob(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	tmpIn, cleanup, err := readUpscaleRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	id := uuid.NewString()
+	s.jobsMu.Lock()
+	s.jobs[id] = &jobStatus{ID: id}
+	s.jobsMu.Unlock()
+
+	// upscaylFile acquires its own worker-pool slot and, with a
+	// never-cancelled context, blocks until one frees up rather than
+	// rejecting outright — the async /jobs endpoint is meant to queue
+	// overflow, unlike the synchronous /upscale path. It takes ownership
+	// of cleanup and runs it once the native call finishes.
+	go func() {
+		tmpOut := tmpIn + "_out.png"
+		outputPath, err := s.upscaylFile(context.Background(), tmpIn, tmpOut, cleanup)
+		s.jobsMu.Lock()
+		defer s.jobsMu.Unlock()
+		job := s.jobs[id]
+		job.Done = true
+		if err != nil {
+			job.Error = err.Error()
+			return
+		}
+		job.OutputPath = outputPath
+	}()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(jobStatus{ID: id})
+}
+
+func (s *server) handleJobStatus(w http.ResponseWriter, r *http.Request) {
+	id := r.URL.Path[len("/jobs/"):]
+	s.jobsMu.Lock()
+	job, ok := s.jobs[id]
+	s.jobsMu.Unlock()
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(job)
+}
+
+func getServeCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Run upscayl as a self-hosted HTTP image super-resolution service",
+		Run: func(cmd *cobra.Command, args []string) {
+			addr, _ := cmd.Flags().GetString("addr")
+			maxConcurrent, _ := cmd.Flags().GetInt("max-concurrent")
+			model, _ := cmd.Flags().GetString("model-name")
+			modelPath, _ := cmd.Flags().GetString("model-path")
+
+			if maxConcurrent <= 0 {
+				maxConcurrent = defaultMaxConcurrent()
+			}
+
+			srv := newServer(maxConcurrent, model, modelPath)
+
+			mux := http.NewServeMux()
+			mux.HandleFunc("/healthz", srv.handleHealthz)
+			mux.HandleFunc("/upscale", srv.handleUpscale)
+			mux.HandleFunc("/jobs", srv.handleSubmitJob)
+			mux.HandleFunc("/jobs/", srv.handleJobStatus)
+			mux.Handle("/metrics", promhttp.Handler())
+
+			log.Println("serving upscayl on", addr, "with max-concurrent =", maxConcurrent)
+			if err := http.ListenAndServe(addr, mux); err != nil {
+				log.Fatal("serve: ", err.Error())
+			}
+		},
+	}
+	cmd.Flags().StringP("addr", "a", ":8080", "Address to listen on")
+	cmd.Flags().Int("max-concurrent", 0, "Maximum number of upscayl jobs running at once (default=runtime.NumCPU()/2)")
+	cmd.Flags().StringP("model-path", "m", "models", "Folder path to the pre-trained models")
+	cmd.Flags().StringP("model-name", "n", "realesrgan-x4plus", "Model name")
+	return cmd
+}
+
+func defaultMaxConcurrent() int {
+	n := runtime.NumCPU() / 2
+	if n < 1 {
+		n = 1
+	}
+	return n
+}
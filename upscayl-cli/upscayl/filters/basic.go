@@ -0,0 +1,163 @@
+package filters
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// Grayscale converts the image to grayscale using a standard luminance
+// weighting.
+type Grayscale struct{}
+
+func (Grayscale) Name() string { return "grayscale" }
+
+func (Grayscale) Apply(img image.Image) image.Image {
+	b := img.Bounds()
+	dst := image.NewGray(b)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			dst.Set(x, y, color.GrayModel.Convert(img.At(x, y)))
+		}
+	}
+	return dst
+}
+
+// Invert produces the photographic negative of the image.
+type Invert struct{}
+
+func (Invert) Name() string { return "invert" }
+
+func (Invert) Apply(img image.Image) image.Image {
+	return mapPixels(img, func(r, g, b, a uint32) (uint32, uint32, uint32, uint32) {
+		return 0xffff - r, 0xffff - g, 0xffff - b, a
+	})
+}
+
+// Brightness shifts every channel by Amount, in the range [-100, 100].
+type Brightness struct {
+	Amount float64
+}
+
+func (Brightness) Name() string { return "brightness" }
+
+func (f Brightness) Apply(img image.Image) image.Image {
+	shift := f.Amount / 100 * 0xffff
+	return mapPixels(img, func(r, g, b, a uint32) (uint32, uint32, uint32, uint32) {
+		return clampShift(r, shift), clampShift(g, shift), clampShift(b, shift), a
+	})
+}
+
+// Contrast scales channel deviation from mid-gray by (1 + Amount/100).
+type Contrast struct {
+	Amount float64
+}
+
+func (Contrast) Name() string { return "contrast" }
+
+func (f Contrast) Apply(img image.Image) image.Image {
+	factor := 1 + f.Amount/100
+	return mapPixels(img, func(r, g, b, a uint32) (uint32, uint32, uint32, uint32) {
+		return clampContrast(r, factor), clampContrast(g, factor), clampContrast(b, factor), a
+	})
+}
+
+// Saturate scales the distance of each pixel from its own grayscale
+// value by (1 + Amount/100).
+type Saturate struct {
+	Amount float64
+}
+
+func (Saturate) Name() string { return "saturate" }
+
+func (f Saturate) Apply(img image.Image) image.Image {
+	factor := 1 + f.Amount/100
+	return mapPixels(img, func(r, g, b, a uint32) (uint32, uint32, uint32, uint32) {
+		gray := 0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)
+		return clampSat(r, gray, factor), clampSat(g, gray, factor), clampSat(b, gray, factor), a
+	})
+}
+
+// Hue rotates the hue of every pixel by Degrees.
+type Hue struct {
+	Degrees float64
+}
+
+func (Hue) Name() string { return "hue" }
+
+func (f Hue) Apply(img image.Image) image.Image {
+	b := img.Bounds()
+	dst := image.NewRGBA(b)
+	theta := f.Degrees * math.Pi / 180
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r, g, bch, a := img.At(x, y).RGBA()
+			nr, ng, nb := rotateHue(float64(r), float64(g), float64(bch), theta)
+			dst.SetRGBA(x, y, color.RGBA{uint8(uint32(nr) >> 8), uint8(uint32(ng) >> 8), uint8(uint32(nb) >> 8), uint8(a >> 8)})
+		}
+	}
+	return dst
+}
+
+func mapPixels(img image.Image, f func(r, g, b, a uint32) (uint32, uint32, uint32, uint32)) image.Image {
+	bounds := img.Bounds()
+	dst := image.NewRGBA(bounds)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, a := img.At(x, y).RGBA()
+			nr, ng, nb, na := f(r, g, b, a)
+			dst.SetRGBA(x, y, color.RGBA{uint8(nr >> 8), uint8(ng >> 8), uint8(nb >> 8), uint8(na >> 8)})
+		}
+	}
+	return dst
+}
+
+func clampShift(v uint32, shift float64) uint32 {
+	return clampU16(float64(v) + shift)
+}
+
+func clampContrast(v uint32, factor float64) uint32 {
+	return clampU16((float64(v)-0x7fff)*factor + 0x7fff)
+}
+
+func clampSat(v uint32, gray, factor float64) uint32 {
+	return clampU16(gray + (float64(v)-gray)*factor)
+}
+
+func clampU16(v float64) uint32 {
+	if v < 0 {
+		return 0
+	}
+	if v > 0xffff {
+		return 0xffff
+	}
+	return uint32(v)
+}
+
+// rotateHue rotates an RGB color around the hue axis by theta radians,
+// using the standard NTSC luminance-preserving rotation matrix.
+func rotateHue(r, g, b, theta float64) (float64, float64, float64) {
+	cosA := math.Cos(theta)
+	sinA := math.Sin(theta)
+
+	m := [3][3]float64{
+		{0.299 + 0.701*cosA + 0.168*sinA, 0.587 - 0.587*cosA + 0.330*sinA, 0.114 - 0.114*cosA - 0.497*sinA},
+		{0.299 - 0.299*cosA - 0.328*sinA, 0.587 + 0.413*cosA + 0.035*sinA, 0.114 - 0.114*cosA + 0.292*sinA},
+		{0.299 - 0.300*cosA + 1.250*sinA, 0.587 - 0.588*cosA - 1.050*sinA, 0.114 + 0.886*cosA - 0.203*sinA},
+	}
+
+	nr := m[0][0]*r + m[0][1]*g + m[0][2]*b
+	ng := m[1][0]*r + m[1][1]*g + m[1][2]*b
+	nb := m[2][0]*r + m[2][1]*g + m[2][2]*b
+	return clampF(nr), clampF(ng), clampF(nb)
+}
+
+func clampF(v float64) float64 {
+	if v < 0 {
+		return 0
+	}
+	if v > 0xffff {
+		return 0xffff
+	}
+	return v
+}
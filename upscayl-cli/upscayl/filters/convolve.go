@@ -0,0 +1,225 @@
+package filters
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// GaussianBlur applies a separable gaussian blur with the given standard
+// deviation (sigma).
+type GaussianBlur struct {
+	Sigma float64
+}
+
+func (GaussianBlur) Name() string { return "gaussian-blur" }
+
+func (f GaussianBlur) Apply(img image.Image) image.Image {
+	if f.Sigma <= 0 {
+		return img
+	}
+	kernel := gaussianKernel(f.Sigma)
+	return convolveSeparable(img, kernel)
+}
+
+// UnsharpMask sharpens the image by adding back Amount times the
+// difference between the image and a gaussian-blurred copy of it
+// (radius Sigma), ignoring differences below Threshold.
+type UnsharpMask struct {
+	Sigma     float64
+	Amount    float64
+	Threshold float64
+}
+
+func (UnsharpMask) Name() string { return "unsharp" }
+
+func (f UnsharpMask) Apply(img image.Image) image.Image {
+	blurred := GaussianBlur{Sigma: f.Sigma}.Apply(img)
+	b := img.Bounds()
+	dst := image.NewRGBA(b)
+	thresh := f.Threshold * 0xffff
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r1, g1, b1, a1 := img.At(x, y).RGBA()
+			r2, g2, b2, _ := blurred.At(x, y).RGBA()
+			nr := sharpenChannel(r1, r2, f.Amount, thresh)
+			ng := sharpenChannel(g1, g2, f.Amount, thresh)
+			nb := sharpenChannel(b1, b2, f.Amount, thresh)
+			dst.SetRGBA(x, y, color.RGBA{uint8(nr >> 8), uint8(ng >> 8), uint8(nb >> 8), uint8(a1 >> 8)})
+		}
+	}
+	return dst
+}
+
+func sharpenChannel(orig, blurred uint32, amount, threshold float64) uint32 {
+	diff := float64(orig) - float64(blurred)
+	if math.Abs(diff) < threshold {
+		return orig
+	}
+	return clampU16(float64(orig) + diff*amount)
+}
+
+// Sigmoid applies a logistic contrast curve, the same family used by
+// ImageMagick's -sigmoidal-contrast: pixels are remapped through a
+// sigmoid centered at Midpoint with steepness Contrast.
+type Sigmoid struct {
+	Contrast float64
+	Midpoint float64
+}
+
+func (Sigmoid) Name() string { return "sigmoid" }
+
+func (f Sigmoid) Apply(img image.Image) image.Image {
+	lut := sigmoidLUT(f.Contrast, f.Midpoint)
+	return mapPixels(img, func(r, g, b, a uint32) (uint32, uint32, uint32, uint32) {
+		return lut[r>>8], lut[g>>8], lut[b>>8], a
+	})
+}
+
+func sigmoidLUT(contrast, midpoint float64) [256]uint32 {
+	var lut [256]uint32
+	sig := func(x float64) float64 {
+		return 1 / (1 + math.Exp(contrast*(midpoint-x)))
+	}
+	lo, hi := sig(0), sig(1)
+	for i := 0; i < 256; i++ {
+		x := float64(i) / 255
+		v := (sig(x) - lo) / (hi - lo)
+		lut[i] = clampU16(v * 0xffff)
+	}
+	return lut
+}
+
+// Pixelate replaces each BlockSize x BlockSize block of pixels with its
+// average color, producing a mosaic effect.
+type Pixelate struct {
+	BlockSize int
+}
+
+func (Pixelate) Name() string { return "pixelate" }
+
+func (f Pixelate) Apply(img image.Image) image.Image {
+	size := f.BlockSize
+	if size < 1 {
+		size = 1
+	}
+	b := img.Bounds()
+	dst := image.NewRGBA(b)
+	for by := b.Min.Y; by < b.Max.Y; by += size {
+		for bx := b.Min.X; bx < b.Max.X; bx += size {
+			var sr, sg, sb, sa, n uint64
+			maxY := min(by+size, b.Max.Y)
+			maxX := min(bx+size, b.Max.X)
+			for y := by; y < maxY; y++ {
+				for x := bx; x < maxX; x++ {
+					r, g, bl, a := img.At(x, y).RGBA()
+					sr += uint64(r)
+					sg += uint64(g)
+					sb += uint64(bl)
+					sa += uint64(a)
+					n++
+				}
+			}
+			if n == 0 {
+				continue
+			}
+			avg := color.RGBA{
+				uint8((sr / n) >> 8),
+				uint8((sg / n) >> 8),
+				uint8((sb / n) >> 8),
+				uint8((sa / n) >> 8),
+			}
+			for y := by; y < maxY; y++ {
+				for x := bx; x < maxX; x++ {
+					dst.SetRGBA(x, y, avg)
+				}
+			}
+		}
+	}
+	return dst
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// gaussianKernel builds a normalized 1D gaussian kernel covering
+// +/-3*sigma.
+func gaussianKernel(sigma float64) []float64 {
+	radius := int(math.Ceil(sigma * 3))
+	if radius < 1 {
+		radius = 1
+	}
+	kernel := make([]float64, 2*radius+1)
+	sum := 0.0
+	for i := -radius; i <= radius; i++ {
+		v := math.Exp(-float64(i*i) / (2 * sigma * sigma))
+		kernel[i+radius] = v
+		sum += v
+	}
+	for i := range kernel {
+		kernel[i] /= sum
+	}
+	return kernel
+}
+
+// convolveSeparable applies a 1D kernel horizontally then vertically,
+// clamping at the image edges.
+func convolveSeparable(img image.Image, kernel []float64) image.Image {
+	b := img.Bounds()
+	radius := len(kernel) / 2
+
+	horiz := image.NewRGBA(b)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			var sr, sg, sb, sa float64
+			for k := -radius; k <= radius; k++ {
+				sx := clampInt(x+k, b.Min.X, b.Max.X-1)
+				r, g, bl, a := img.At(sx, y).RGBA()
+				w := kernel[k+radius]
+				sr += float64(r) * w
+				sg += float64(g) * w
+				sb += float64(bl) * w
+				sa += float64(a) * w
+			}
+			horiz.SetRGBA(x, y, color.RGBA{
+				uint8(clampU16(sr) >> 8), uint8(clampU16(sg) >> 8),
+				uint8(clampU16(sb) >> 8), uint8(clampU16(sa) >> 8),
+			})
+		}
+	}
+
+	dst := image.NewRGBA(b)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			var sr, sg, sb, sa float64
+			for k := -radius; k <= radius; k++ {
+				sy := clampInt(y+k, b.Min.Y, b.Max.Y-1)
+				r, g, bl, a := horiz.At(x, sy).RGBA()
+				w := kernel[k+radius]
+				sr += float64(r) * w
+				sg += float64(g) * w
+				sb += float64(bl) * w
+				sa += float64(a) * w
+			}
+			dst.SetRGBA(x, y, color.RGBA{
+				uint8(clampU16(sr) >> 8), uint8(clampU16(sg) >> 8),
+				uint8(clampU16(sb) >> 8), uint8(clampU16(sa) >> 8),
+			})
+		}
+	}
+	return dst
+}
+
+func clampInt(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
@@ -0,0 +1,22 @@
+package filters
+
+import (
+	"image"
+	"image/draw"
+)
+
+// Crop extracts the rectangle (X, Y)-(X+Width, Y+Height) from the
+// image.
+type Crop struct {
+	X, Y, Width, Height int
+}
+
+func (Crop) Name() string { return "crop" }
+
+func (f Crop) Apply(img image.Image) image.Image {
+	b := img.Bounds()
+	rect := image.Rect(b.Min.X+f.X, b.Min.Y+f.Y, b.Min.X+f.X+f.Width, b.Min.Y+f.Y+f.Height).Intersect(b)
+	dst := image.NewRGBA(image.Rect(0, 0, rect.Dx(), rect.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, rect.Min, draw.Src)
+	return dst
+}
@@ -0,0 +1,25 @@
+// Package filters implements a composable chain of post-upscayl image
+// transformations (color grading, sharpening, resizing, ...) applied
+// on top of image/draw.
+package filters
+
+import "image"
+
+// Filter is a single named image transformation. Implementations must
+// not mutate the image they are given; they return a new image.
+type Filter interface {
+	Apply(img image.Image) image.Image
+	Name() string
+}
+
+// Pipeline is an ordered chain of filters applied one after another.
+type Pipeline []Filter
+
+// Run applies every filter in order, feeding each filter's output into
+// the next.
+func (p Pipeline) Run(img image.Image) image.Image {
+	for _, f := range p {
+		img = f.Apply(img)
+	}
+	return img
+}
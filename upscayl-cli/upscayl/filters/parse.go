@@ -0,0 +1,176 @@
+package filters
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Parse turns a single `--filter` spec such as "grayscale",
+// "gaussian-blur:3", or "unsharp:1.5,1.0,0.02" into a Filter.
+func Parse(spec string) (Filter, error) {
+	name, rawArgs, _ := strings.Cut(spec, ":")
+	var args []string
+	if rawArgs != "" {
+		args = strings.Split(rawArgs, ",")
+	}
+
+	switch name {
+	case "grayscale":
+		return Grayscale{}, nil
+	case "invert":
+		return Invert{}, nil
+	case "brightness":
+		amount, err := floatArg(args, 0, "brightness")
+		if err != nil {
+			return nil, err
+		}
+		return Brightness{Amount: amount}, nil
+	case "contrast":
+		amount, err := floatArg(args, 0, "contrast")
+		if err != nil {
+			return nil, err
+		}
+		return Contrast{Amount: amount}, nil
+	case "saturate":
+		amount, err := floatArg(args, 0, "saturate")
+		if err != nil {
+			return nil, err
+		}
+		return Saturate{Amount: amount}, nil
+	case "hue":
+		degrees, err := floatArg(args, 0, "hue")
+		if err != nil {
+			return nil, err
+		}
+		return Hue{Degrees: degrees}, nil
+	case "gaussian-blur":
+		sigma, err := floatArg(args, 0, "gaussian-blur")
+		if err != nil {
+			return nil, err
+		}
+		return GaussianBlur{Sigma: sigma}, nil
+	case "unsharp":
+		sigma, err := floatArg(args, 0, "unsharp")
+		if err != nil {
+			return nil, err
+		}
+		amount, err := floatArgDefault(args, 1, 1.0)
+		if err != nil {
+			return nil, err
+		}
+		threshold, err := floatArgDefault(args, 2, 0.0)
+		if err != nil {
+			return nil, err
+		}
+		return UnsharpMask{Sigma: sigma, Amount: amount, Threshold: threshold}, nil
+	case "sigmoid":
+		contrast, err := floatArg(args, 0, "sigmoid")
+		if err != nil {
+			return nil, err
+		}
+		midpoint, err := floatArgDefault(args, 1, 0.5)
+		if err != nil {
+			return nil, err
+		}
+		return Sigmoid{Contrast: contrast, Midpoint: midpoint}, nil
+	case "pixelate":
+		size, err := intArg(args, 0, "pixelate")
+		if err != nil {
+			return nil, err
+		}
+		return Pixelate{BlockSize: size}, nil
+	case "resize":
+		w, h, err := parseGeometry(rawArgs)
+		if err != nil {
+			return nil, err
+		}
+		return Resize{Width: w, Height: h}, nil
+	case "crop":
+		x, y, w, h, err := parseCrop(args)
+		if err != nil {
+			return nil, err
+		}
+		return Crop{X: x, Y: y, Width: w, Height: h}, nil
+	default:
+		return nil, fmt.Errorf("unknown filter %q", name)
+	}
+}
+
+// ParsePipeline parses every --filter spec, in order, into a Pipeline.
+func ParsePipeline(specs []string) (Pipeline, error) {
+	pipeline := make(Pipeline, 0, len(specs))
+	for _, spec := range specs {
+		f, err := Parse(spec)
+		if err != nil {
+			return nil, err
+		}
+		pipeline = append(pipeline, f)
+	}
+	return pipeline, nil
+}
+
+// parseGeometry parses ImageMagick-style geometry for the resize filter:
+// "WxH", "Wx", "xH".
+func parseGeometry(geometry string) (w, h int, err error) {
+	parts := strings.SplitN(geometry, "x", 2)
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("resize: expected WxH, Wx, or xH geometry, got %q", geometry)
+	}
+	if parts[0] != "" {
+		w, err = strconv.Atoi(parts[0])
+		if err != nil {
+			return 0, 0, fmt.Errorf("resize: invalid width %q", parts[0])
+		}
+	}
+	if parts[1] != "" {
+		h, err = strconv.Atoi(parts[1])
+		if err != nil {
+			return 0, 0, fmt.Errorf("resize: invalid height %q", parts[1])
+		}
+	}
+	return w, h, nil
+}
+
+func parseCrop(args []string) (x, y, w, h int, err error) {
+	if len(args) != 4 {
+		return 0, 0, 0, 0, fmt.Errorf("crop: expected x,y,width,height")
+	}
+	vals := make([]int, 4)
+	for i, a := range args {
+		vals[i], err = strconv.Atoi(strings.TrimSpace(a))
+		if err != nil {
+			return 0, 0, 0, 0, fmt.Errorf("crop: invalid value %q", a)
+		}
+	}
+	return vals[0], vals[1], vals[2], vals[3], nil
+}
+
+func floatArg(args []string, i int, name string) (float64, error) {
+	if i >= len(args) {
+		return 0, fmt.Errorf("%s: expected an argument", name)
+	}
+	v, err := strconv.ParseFloat(strings.TrimSpace(args[i]), 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s: invalid argument %q", name, args[i])
+	}
+	return v, nil
+}
+
+func floatArgDefault(args []string, i int, def float64) (float64, error) {
+	if i >= len(args) {
+		return def, nil
+	}
+	return strconv.ParseFloat(strings.TrimSpace(args[i]), 64)
+}
+
+func intArg(args []string, i int, name string) (int, error) {
+	if i >= len(args) {
+		return 0, fmt.Errorf("%s: expected an argument", name)
+	}
+	v, err := strconv.Atoi(strings.TrimSpace(args[i]))
+	if err != nil {
+		return 0, fmt.Errorf("%s: invalid argument %q", name, args[i])
+	}
+	return v, nil
+}
@@ -0,0 +1,165 @@
+package preproc
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// Deskew straightens a scanned page by finding the dominant line angle
+// among its edge pixels within +/-MaxAngle (default 15 degrees) and
+// rotating the image by the negative of that angle using bilinear
+// interpolation.
+type Deskew struct {
+	MaxAngle float64
+}
+
+func (Deskew) Name() string { return "deskew" }
+
+func (d Deskew) Apply(img image.Image) image.Image {
+	maxAngle := d.MaxAngle
+	if maxAngle <= 0 {
+		maxAngle = 15
+	}
+
+	edges := sobelEdges(toGray(img))
+	angle := dominantAngle(edges, maxAngle)
+	if angle == 0 {
+		return img
+	}
+	return rotateBilinear(img, -angle)
+}
+
+// sobelEdges returns an edge mask (255 = edge, 0 = not) using the Sobel
+// gradient magnitude thresholded at a fixed level, good enough to feed a
+// Hough vote without a full Canny pipeline.
+func sobelEdges(gray *image.Gray) *image.Gray {
+	b := gray.Bounds()
+	w, h := b.Dx(), b.Dy()
+	edges := image.NewGray(b)
+
+	gx := [3][3]int{{-1, 0, 1}, {-2, 0, 2}, {-1, 0, 1}}
+	gy := [3][3]int{{-1, -2, -1}, {0, 0, 0}, {1, 2, 1}}
+
+	for y := 1; y < h-1; y++ {
+		for x := 1; x < w-1; x++ {
+			var sx, sy int
+			for ky := -1; ky <= 1; ky++ {
+				for kx := -1; kx <= 1; kx++ {
+					v := int(gray.GrayAt(b.Min.X+x+kx, b.Min.Y+y+ky).Y)
+					sx += v * gx[ky+1][kx+1]
+					sy += v * gy[ky+1][kx+1]
+				}
+			}
+			mag := int(math.Sqrt(float64(sx*sx + sy*sy)))
+			if mag > 128 {
+				edges.SetGray(b.Min.X+x, b.Min.Y+y, color.Gray{Y: 255})
+			}
+		}
+	}
+	return edges
+}
+
+// dominantAngle runs a coarse Hough transform restricted to
+// +/-maxAngleDeg, in 0.5 degree steps, and returns the angle (in
+// degrees) whose family of lines collects the most edge-pixel votes.
+func dominantAngle(edges *image.Gray, maxAngleDeg float64) float64 {
+	b := edges.Bounds()
+	w, h := b.Dx(), b.Dy()
+	diag := int(math.Hypot(float64(w), float64(h))) + 1
+
+	const step = 0.5
+	numAngles := int(2*maxAngleDeg/step) + 1
+	votes := make([][]int, numAngles)
+	for i := range votes {
+		votes[i] = make([]int, 2*diag+1)
+	}
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if edges.GrayAt(b.Min.X+x, b.Min.Y+y).Y == 0 {
+				continue
+			}
+			for i := 0; i < numAngles; i++ {
+				deg := -maxAngleDeg + float64(i)*step
+				theta := deg * math.Pi / 180
+				rho := int(float64(x)*math.Cos(theta)+float64(y)*math.Sin(theta)) + diag
+				if rho >= 0 && rho < len(votes[i]) {
+					votes[i][rho]++
+				}
+			}
+		}
+	}
+
+	bestAngleIdx, bestVotes := 0, 0
+	for i, row := range votes {
+		for _, v := range row {
+			if v > bestVotes {
+				bestVotes = v
+				bestAngleIdx = i
+			}
+		}
+	}
+	if bestVotes == 0 {
+		// No edges at all (blank page, flat denoised/binarized region):
+		// every bucket ties at zero votes, so there's no real dominant
+		// angle to rotate toward.
+		return 0
+	}
+	return -maxAngleDeg + float64(bestAngleIdx)*step
+}
+
+func rotateBilinear(img image.Image, degrees float64) image.Image {
+	b := img.Bounds()
+	theta := degrees * math.Pi / 180
+	cosT, sinT := math.Cos(theta), math.Sin(theta)
+	cx, cy := float64(b.Dx())/2, float64(b.Dy())/2
+
+	dst := image.NewRGBA(b)
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			dx := float64(x) - cx
+			dy := float64(y) - cy
+			sx := dx*cosT + dy*sinT + cx
+			sy := -dx*sinT + dy*cosT + cy
+			if sx < 0 || sy < 0 || sx >= float64(b.Dx()-1) || sy >= float64(b.Dy()-1) {
+				dst.Set(b.Min.X+x, b.Min.Y+y, color.White)
+				continue
+			}
+			dst.Set(b.Min.X+x, b.Min.Y+y, bilinearSampleAt(img, b, sx, sy))
+		}
+	}
+	return dst
+}
+
+// bilinearSampleAt samples img at floating point coordinates (sx, sy),
+// relative to bounds b, blending the four nearest pixels.
+func bilinearSampleAt(img image.Image, b image.Rectangle, sx, sy float64) color.Color {
+	x0 := int(sx)
+	y0 := int(sy)
+	x1 := clampI(x0+1, b.Min.X, b.Max.X-1)
+	y1 := clampI(y0+1, b.Min.Y, b.Max.Y-1)
+	x0 = clampI(x0, b.Min.X, b.Max.X-1)
+	y0 = clampI(y0, b.Min.Y, b.Max.Y-1)
+
+	fx := sx - float64(x0)
+	fy := sy - float64(y0)
+
+	r00, g00, bl00, a00 := img.At(x0, y0).RGBA()
+	r10, g10, bl10, a10 := img.At(x1, y0).RGBA()
+	r01, g01, bl01, a01 := img.At(x0, y1).RGBA()
+	r11, g11, bl11, a11 := img.At(x1, y1).RGBA()
+
+	blend := func(v00, v10, v01, v11 uint32) uint8 {
+		top := float64(v00)*(1-fx) + float64(v10)*fx
+		bottom := float64(v01)*(1-fx) + float64(v11)*fx
+		return uint8((top*(1-fy) + bottom*fy) / 256)
+	}
+
+	return color.RGBA{
+		blend(r00, r10, r01, r11),
+		blend(g00, g10, g01, g11),
+		blend(bl00, bl10, bl01, bl11),
+		blend(a00, a10, a01, a11),
+	}
+}
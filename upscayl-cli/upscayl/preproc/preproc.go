@@ -0,0 +1,67 @@
+// Package preproc implements classical document image-processing steps
+// (binarization, border wipe, deskew, denoise) applied before, and
+// optionally after, upscayl's super-resolution pass. These are the usual
+// cleanup steps for scanned book pages headed into an OCR pipeline.
+package preproc
+
+import (
+	"fmt"
+	"image"
+	"strings"
+)
+
+// Step is a single preprocessing operation.
+type Step interface {
+	Apply(img image.Image) image.Image
+	Name() string
+}
+
+// Options configures the tunable steps. Zero values fall back to the
+// defaults documented on the `preproc` command's flags.
+type Options struct {
+	SauvolaWindow int
+	WipeThreshold float64
+}
+
+// Parse turns a comma-separated `--preproc` spec such as
+// "deskew,denoise,binarize,wipe-borders" into an ordered chain of Steps.
+func Parse(spec string, opts Options) ([]Step, error) {
+	if spec == "" {
+		return nil, nil
+	}
+	if opts.SauvolaWindow <= 0 {
+		opts.SauvolaWindow = 19
+	}
+	if opts.WipeThreshold <= 0 {
+		opts.WipeThreshold = 0.95
+	}
+
+	var steps []Step
+	for _, name := range strings.Split(spec, ",") {
+		name = strings.TrimSpace(name)
+		switch name {
+		case "binarize":
+			steps = append(steps, SauvolaBinarize{Window: opts.SauvolaWindow, K: 0.3, R: 128})
+		case "wipe-borders":
+			steps = append(steps, WipeBorders{Threshold: opts.WipeThreshold})
+		case "deskew":
+			steps = append(steps, Deskew{})
+		case "denoise":
+			steps = append(steps, MedianDenoise{})
+		case "":
+			continue
+		default:
+			return nil, fmt.Errorf("preproc: unknown step %q", name)
+		}
+	}
+	return steps, nil
+}
+
+// Run applies every step in order, feeding each step's output into the
+// next.
+func Run(steps []Step, img image.Image) image.Image {
+	for _, s := range steps {
+		img = s.Apply(img)
+	}
+	return img
+}
@@ -0,0 +1,113 @@
+package preproc
+
+import (
+	"image"
+	_ "image/png"
+	"os"
+	"testing"
+)
+
+func loadTestdata(t *testing.T, name string) image.Image {
+	t.Helper()
+	f, err := os.Open("testdata/" + name)
+	if err != nil {
+		t.Fatalf("opening %s: %v", name, err)
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		t.Fatalf("decoding %s: %v", name, err)
+	}
+	return img
+}
+
+func TestMedianDenoiseRemovesSaltAndPepper(t *testing.T) {
+	img := loadTestdata(t, "noisy.png")
+	out := MedianDenoise{}.Apply(img)
+
+	gray, ok := out.(*image.Gray)
+	if !ok {
+		t.Fatalf("expected *image.Gray output, got %T", out)
+	}
+
+	// The fixture is a uniform 200-gray field with a single black pixel
+	// at (3,3) and a single white pixel at (5,4); a 3x3 median filter
+	// should wash both outliers back to the surrounding value.
+	if v := gray.GrayAt(3, 3).Y; v != 200 {
+		t.Errorf("pepper pixel at (3,3): got %d, want 200", v)
+	}
+	if v := gray.GrayAt(5, 4).Y; v != 200 {
+		t.Errorf("salt pixel at (5,4): got %d, want 200", v)
+	}
+}
+
+func TestWipeBordersClearsGutterButKeepsInterior(t *testing.T) {
+	img := loadTestdata(t, "bordered.png")
+	out := WipeBorders{Threshold: 0.5}.Apply(img)
+
+	gray, ok := out.(*image.Gray)
+	if !ok {
+		t.Fatalf("expected *image.Gray output, got %T", out)
+	}
+
+	// The fixture has a solid 2px black gutter on every edge.
+	for _, p := range [][2]int{{0, 0}, {9, 0}, {0, 9}, {9, 9}, {1, 5}} {
+		if v := gray.GrayAt(p[0], p[1]).Y; v != 255 {
+			t.Errorf("border pixel at (%d,%d): got %d, want 255 (wiped)", p[0], p[1], v)
+		}
+	}
+
+	// The interior "text" pixel must survive the wipe.
+	if v := gray.GrayAt(5, 5).Y; v != 100 {
+		t.Errorf("interior pixel at (5,5): got %d, want 100 (untouched)", v)
+	}
+}
+
+func TestSauvolaBinarizeProducesOnlyBlackOrWhite(t *testing.T) {
+	img := loadTestdata(t, "bordered.png")
+	out := SauvolaBinarize{Window: 5, K: 0.3, R: 128}.Apply(img)
+
+	gray, ok := out.(*image.Gray)
+	if !ok {
+		t.Fatalf("expected *image.Gray output, got %T", out)
+	}
+
+	b := gray.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			v := gray.GrayAt(x, y).Y
+			if v != 0 && v != 255 {
+				t.Fatalf("pixel (%d,%d) = %d, want pure black or white", x, y, v)
+			}
+		}
+	}
+}
+
+func TestDeskewLeavesBlankPageUntouched(t *testing.T) {
+	img := loadTestdata(t, "blank.png")
+	out := Deskew{}.Apply(img)
+
+	gray, ok := out.(*image.Gray)
+	if !ok {
+		t.Fatalf("expected blank page to pass through untouched as *image.Gray, got %T", out)
+	}
+
+	// A uniform-gray page has no edges to vote on, so dominantAngle must
+	// fall back to 0 rather than the -MaxAngle sentinel bug would have
+	// produced; Apply should then return the source image unrotated,
+	// with no white-filled corners.
+	b := gray.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if v := gray.GrayAt(x, y).Y; v != 200 {
+				t.Fatalf("pixel (%d,%d) = %d, want 200 (untouched)", x, y, v)
+			}
+		}
+	}
+}
+
+func TestParseUnknownStep(t *testing.T) {
+	if _, err := Parse("not-a-real-step", Options{}); err == nil {
+		t.Fatal("expected an error for an unknown preproc step")
+	}
+}
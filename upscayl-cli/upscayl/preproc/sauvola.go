@@ -0,0 +1,128 @@
+package preproc
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// SauvolaBinarize converts the image to black-and-white using Sauvola's
+// adaptive thresholding: T(x,y) = mean(x,y) * (1 + K*(std(x,y)/R - 1)),
+// computed over a Window x Window neighborhood around each pixel.
+//
+// Mean and variance are computed in O(1) per pixel via integral images
+// of the intensity and squared intensity, so the whole pass is O(W*H)
+// regardless of window size.
+type SauvolaBinarize struct {
+	Window int
+	K      float64
+	R      float64
+}
+
+func (SauvolaBinarize) Name() string { return "binarize" }
+
+func (s SauvolaBinarize) Apply(img image.Image) image.Image {
+	window := s.Window
+	if window < 1 {
+		window = 19
+	}
+	k := s.K
+	if k == 0 {
+		k = 0.3
+	}
+	r := s.R
+	if r == 0 {
+		r = 128
+	}
+
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	gray := toGray(img)
+
+	sum, sqSum := buildIntegralImages(gray, w, h)
+	stride := w + 1
+
+	radius := window / 2
+	dst := image.NewGray(b)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			x0 := clampI(x-radius, 0, w-1)
+			x1 := clampI(x+radius, 0, w-1)
+			y0 := clampI(y-radius, 0, h-1)
+			y1 := clampI(y+radius, 0, h-1)
+			count := float64((x1 - x0 + 1) * (y1 - y0 + 1))
+
+			areaSum := regionSum(sum, stride, x0, y0, x1, y1)
+			areaSqSum := regionSum(sqSum, stride, x0, y0, x1, y1)
+
+			mean := areaSum / count
+			variance := areaSqSum/count - mean*mean
+			if variance < 0 {
+				variance = 0
+			}
+			std := math.Sqrt(variance)
+
+			threshold := mean * (1 + k*(std/r-1))
+
+			v := gray.GrayAt(b.Min.X+x, b.Min.Y+y).Y
+			if float64(v) > threshold {
+				dst.SetGray(b.Min.X+x, b.Min.Y+y, color.Gray{Y: 255})
+			} else {
+				dst.SetGray(b.Min.X+x, b.Min.Y+y, color.Gray{Y: 0})
+			}
+		}
+	}
+	return dst
+}
+
+func toGray(img image.Image) *image.Gray {
+	b := img.Bounds()
+	gray := image.NewGray(b)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			gray.Set(x, y, img.At(x, y))
+		}
+	}
+	return gray
+}
+
+// buildIntegralImages returns (w+1)x(h+1) integral images of the pixel
+// intensity and its square, using the standard summed-area-table
+// recurrence so any window's sum is four array lookups away.
+func buildIntegralImages(gray *image.Gray, w, h int) (sum, sqSum []float64) {
+	stride := w + 1
+	sum = make([]float64, stride*(h+1))
+	sqSum = make([]float64, stride*(h+1))
+	b := gray.Bounds()
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			v := float64(gray.GrayAt(b.Min.X+x, b.Min.Y+y).Y)
+			idx := (y+1)*stride + (x + 1)
+			sum[idx] = v + sum[idx-1] + sum[idx-stride] - sum[idx-stride-1]
+			sqSum[idx] = v*v + sqSum[idx-1] + sqSum[idx-stride] - sqSum[idx-stride-1]
+		}
+	}
+	return sum, sqSum
+}
+
+// regionSum reads the sum over [x0,x1]x[y0,y1] (inclusive) from an
+// integral image of the given stride (w+1), built by
+// buildIntegralImages.
+func regionSum(table []float64, stride, x0, y0, x1, y1 int) float64 {
+	a := table[y0*stride+x0]
+	b := table[y0*stride+(x1+1)]
+	c := table[(y1+1)*stride+x0]
+	d := table[(y1+1)*stride+(x1+1)]
+	return d - b - c + a
+}
+
+func clampI(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
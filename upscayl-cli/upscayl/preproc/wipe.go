@@ -0,0 +1,97 @@
+package preproc
+
+import (
+	"image"
+	"image/color"
+)
+
+// WipeBorders scans inward from each edge of the image and zeroes
+// (blackens -> whitens, since documents are dark-text-on-light-page)
+// rows/columns whose black-pixel ratio exceeds Threshold, stopping as
+// soon as a clean row/column is found. This removes the dark gutters
+// and shadows that flatbed scanners leave around the page.
+type WipeBorders struct {
+	Threshold float64
+}
+
+func (WipeBorders) Name() string { return "wipe-borders" }
+
+func (w WipeBorders) Apply(img image.Image) image.Image {
+	threshold := w.Threshold
+	if threshold <= 0 {
+		threshold = 0.95
+	}
+
+	b := img.Bounds()
+	width, height := b.Dx(), b.Dy()
+	gray := toGray(img)
+	dst := image.NewGray(b)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			dst.Set(x, y, gray.At(x, y))
+		}
+	}
+
+	isDarkRow := func(y int) bool {
+		return blackRatio(gray, b, 0, y, width) > threshold
+	}
+	isDarkCol := func(x int) bool {
+		return blackRatioCol(gray, b, x, 0, height) > threshold
+	}
+
+	top := 0
+	for top < height && isDarkRow(top) {
+		top++
+	}
+	bottom := height - 1
+	for bottom >= top && isDarkRow(bottom) {
+		bottom--
+	}
+	left := 0
+	for left < width && isDarkCol(left) {
+		left++
+	}
+	right := width - 1
+	for right >= left && isDarkCol(right) {
+		right--
+	}
+
+	for y := 0; y < height; y++ {
+		if y < top || y > bottom {
+			wipeRow(dst, b, y, width)
+			continue
+		}
+		for x := 0; x < width; x++ {
+			if x < left || x > right {
+				dst.SetGray(b.Min.X+x, b.Min.Y+y, color.Gray{Y: 255})
+			}
+		}
+	}
+	return dst
+}
+
+func blackRatio(gray *image.Gray, b image.Rectangle, xStart, y, width int) float64 {
+	dark := 0
+	for x := xStart; x < xStart+width; x++ {
+		if gray.GrayAt(b.Min.X+x, b.Min.Y+y).Y < 128 {
+			dark++
+		}
+	}
+	return float64(dark) / float64(width)
+}
+
+func blackRatioCol(gray *image.Gray, b image.Rectangle, x, yStart, height int) float64 {
+	dark := 0
+	for y := yStart; y < yStart+height; y++ {
+		if gray.GrayAt(b.Min.X+x, b.Min.Y+y).Y < 128 {
+			dark++
+		}
+	}
+	return float64(dark) / float64(height)
+}
+
+func wipeRow(dst *image.Gray, b image.Rectangle, y, width int) {
+	for x := 0; x < width; x++ {
+		dst.SetGray(b.Min.X+x, b.Min.Y+y, color.Gray{Y: 255})
+	}
+}
@@ -0,0 +1,102 @@
+// Package thumbnail wires upscayl's output through an EXIF-orientation
+// correction and an ImageMagick-style geometry resize, optionally
+// emitting a set of derivative thumbnail sizes from a single pass.
+package thumbnail
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Mode describes how a geometry spec's target dimensions should be
+// reconciled with the source image's aspect ratio.
+type Mode int
+
+const (
+	// ModeFit scales the image to fit entirely inside WxH, preserving
+	// aspect ratio (the "WxH" form).
+	ModeFit Mode = iota
+	// ModeFill scales the image to fill WxH and crops the overflow (the
+	// "WxH^" form).
+	ModeFill
+	// ModeExact stretches the image to exactly WxH, ignoring aspect
+	// ratio (the "WxH!" form).
+	ModeExact
+	// ModeWidth scales to the given width, preserving aspect ratio (the
+	// "Wx" form).
+	ModeWidth
+	// ModeHeight scales to the given height, preserving aspect ratio
+	// (the "xH" form).
+	ModeHeight
+	// ModeThumbSquare produces a centered square crop of the given side
+	// length (the "thumb:N" shorthand).
+	ModeThumbSquare
+)
+
+// Geometry is a parsed `--resize` spec.
+type Geometry struct {
+	Mode   Mode
+	Width  int
+	Height int
+}
+
+// Parse parses ImageMagick-style geometry strings: "WxH" (fit inside),
+// "WxH^" (fill and crop), "WxH!" (exact, ignoring aspect), "Wx" (width
+// only), "xH" (height only), and the "thumb:N" shorthand for a centered
+// square crop of side N.
+func Parse(spec string) (Geometry, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" || spec == "default" {
+		return Geometry{}, nil
+	}
+
+	if rest, ok := strings.CutPrefix(spec, "thumb:"); ok {
+		n, err := strconv.Atoi(rest)
+		if err != nil {
+			return Geometry{}, fmt.Errorf("resize: invalid thumb size %q", rest)
+		}
+		return Geometry{Mode: ModeThumbSquare, Width: n, Height: n}, nil
+	}
+
+	mode := ModeFit
+	switch {
+	case strings.HasSuffix(spec, "^"):
+		mode = ModeFill
+		spec = strings.TrimSuffix(spec, "^")
+	case strings.HasSuffix(spec, "!"):
+		mode = ModeExact
+		spec = strings.TrimSuffix(spec, "!")
+	}
+
+	parts := strings.SplitN(spec, "x", 2)
+	if len(parts) != 2 {
+		return Geometry{}, fmt.Errorf("resize: expected WxH, WxH^, WxH!, Wx, or xH geometry, got %q", spec)
+	}
+
+	var w, h int
+	var err error
+	if parts[0] != "" {
+		w, err = strconv.Atoi(parts[0])
+		if err != nil {
+			return Geometry{}, fmt.Errorf("resize: invalid width %q", parts[0])
+		}
+	}
+	if parts[1] != "" {
+		h, err = strconv.Atoi(parts[1])
+		if err != nil {
+			return Geometry{}, fmt.Errorf("resize: invalid height %q", parts[1])
+		}
+	}
+
+	switch {
+	case w > 0 && h == 0:
+		return Geometry{Mode: ModeWidth, Width: w}, nil
+	case w == 0 && h > 0:
+		return Geometry{Mode: ModeHeight, Height: h}, nil
+	case w > 0 && h > 0:
+		return Geometry{Mode: mode, Width: w, Height: h}, nil
+	default:
+		return Geometry{}, fmt.Errorf("resize: geometry %q specifies neither width nor height", spec)
+	}
+}
@@ -0,0 +1,122 @@
+package thumbnail
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"os"
+
+	"github.com/disintegration/imaging"
+	"github.com/rwcarlsen/goexif/exif"
+)
+
+// ReadOrientation reads the EXIF orientation tag (1-8) from the image at
+// path. It returns 1 (no transform needed) if the file has no EXIF data
+// or no orientation tag, since that's the common case for PNGs and most
+// non-camera-originated images.
+func ReadOrientation(path string) int {
+	f, err := os.Open(path)
+	if err != nil {
+		return 1
+	}
+	defer f.Close()
+
+	x, err := exif.Decode(f)
+	if err != nil {
+		return 1
+	}
+	tag, err := x.Get(exif.Orientation)
+	if err != nil {
+		return 1
+	}
+	orientation, err := tag.Int(0)
+	if err != nil || orientation < 1 || orientation > 8 {
+		return 1
+	}
+	return orientation
+}
+
+// CorrectOrientation applies the rotation/flip implied by an EXIF
+// orientation value (1-8) so that downstream geometry math operates on
+// an already "right side up" image.
+func CorrectOrientation(img image.Image, orientation int) image.Image {
+	switch orientation {
+	case 2:
+		return imaging.FlipH(img)
+	case 3:
+		return imaging.Rotate180(img)
+	case 4:
+		return imaging.FlipV(img)
+	case 5:
+		return imaging.Transpose(img)
+	case 6:
+		return imaging.Rotate270(img)
+	case 7:
+		return imaging.Transverse(img)
+	case 8:
+		return imaging.Rotate90(img)
+	default:
+		return img
+	}
+}
+
+// Resize applies a parsed Geometry to img using Lanczos resampling. When
+// geometry is the zero value (unset/"default"), img is returned
+// unchanged. background is used to letterbox ModeFit results onto an
+// exact WxH canvas, and is ignored for every other mode.
+func Resize(img image.Image, geometry Geometry, background color.Color) image.Image {
+	switch geometry.Mode {
+	case ModeFit:
+		resized := imaging.Fit(img, geometry.Width, geometry.Height, imaging.Lanczos)
+		if background == nil {
+			return resized
+		}
+		return letterbox(resized, geometry.Width, geometry.Height, background)
+	case ModeFill:
+		return imaging.Fill(img, geometry.Width, geometry.Height, imaging.Center, imaging.Lanczos)
+	case ModeExact:
+		return imaging.Resize(img, geometry.Width, geometry.Height, imaging.Lanczos)
+	case ModeWidth:
+		return imaging.Resize(img, geometry.Width, 0, imaging.Lanczos)
+	case ModeHeight:
+		return imaging.Resize(img, 0, geometry.Height, imaging.Lanczos)
+	case ModeThumbSquare:
+		return imaging.Thumbnail(img, geometry.Width, geometry.Height, imaging.Lanczos)
+	default:
+		return img
+	}
+}
+
+// letterbox centers img on a width x height canvas filled with
+// background, used when a ModeFit resize leaves empty space because the
+// source and target aspect ratios differ.
+func letterbox(img image.Image, width, height int, background color.Color) image.Image {
+	canvas := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(canvas, canvas.Bounds(), &image.Uniform{C: background}, image.Point{}, draw.Src)
+
+	b := img.Bounds()
+	offset := image.Pt((width-b.Dx())/2, (height-b.Dy())/2)
+	draw.Draw(canvas, b.Add(offset), img, b.Min, draw.Over)
+	return canvas
+}
+
+// DerivativeName builds the "<base>@<size>.<ext>" filename for a
+// thumbnail derivative, e.g. "photo@256.jpg" from "photo.jpg" and 256.
+func DerivativeName(path string, size int) string {
+	ext := fileExt(path)
+	base := path[:len(path)-len(ext)]
+	return fmt.Sprintf("%s@%d%s", base, size, ext)
+}
+
+func fileExt(path string) string {
+	for i := len(path) - 1; i >= 0; i-- {
+		if path[i] == '.' {
+			return path[i:]
+		}
+		if path[i] == '/' {
+			break
+		}
+	}
+	return ""
+}
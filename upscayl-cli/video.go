@@ -0,0 +1,272 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/spf13/cobra"
+	"github.com/yashschandra/upscayl-cli/upscayl"
+)
+
+// videoProbe is the subset of `ffprobe -print_format json -show_streams`
+// that the video pipeline cares about.
+type videoProbe struct {
+	Width    int
+	Height   int
+	FPS      string
+	Duration float64
+	HasAudio bool
+}
+
+func probeVideo(path string) (*videoProbe, error) {
+	cmd := exec.Command("ffprobe",
+		"-v", "error",
+		"-print_format", "json",
+		"-show_streams",
+		"-show_format",
+		path,
+	)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("ffprobe: %w", err)
+	}
+
+	var probe struct {
+		Streams []struct {
+			CodecType  string `json:"codec_type"`
+			Width      int    `json:"width"`
+			Height     int    `json:"height"`
+			RFrameRate string `json:"r_frame_rate"`
+			PixFmt     string `json:"pix_fmt"`
+		} `json:"streams"`
+		Format struct {
+			Duration string `json:"duration"`
+		} `json:"format"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &probe); err != nil {
+		return nil, fmt.Errorf("parsing ffprobe output: %w", err)
+	}
+
+	result := &videoProbe{}
+	for _, s := range probe.Streams {
+		switch s.CodecType {
+		case "video":
+			result.Width = s.Width
+			result.Height = s.Height
+			result.FPS = s.RFrameRate
+		case "audio":
+			result.HasAudio = true
+		}
+	}
+	fmt.Sscanf(probe.Format.Duration, "%f", &result.Duration)
+	return result, nil
+}
+
+// extractFrames pipes ffmpeg's decoded PNG frames into frameDir, named
+// frame_%08d.png, and returns the total frame count once ffmpeg exits.
+func extractFrames(ctx context.Context, inputPath, frameDir string) error {
+	if err := os.MkdirAll(frameDir, 0o755); err != nil {
+		return err
+	}
+	cmd := exec.CommandContext(ctx, "ffmpeg",
+		"-y",
+		"-i", inputPath,
+		"-f", "image2",
+		"-vcodec", "png",
+		filepath.Join(frameDir, "frame_%08d.png"),
+	)
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// upscaylFrames upscayls every frame found in frameDir into outDir using a
+// bounded pool of concurrent upscayl.Upscayl invocations, reporting
+// progress as frames complete.
+func upscaylFrames(ctx context.Context, frameDir, outDir string, maxConcurrent int, input upscayl.Input) (int, error) {
+	frames, err := filepath.Glob(filepath.Join(frameDir, "frame_*.png"))
+	if err != nil {
+		return 0, err
+	}
+	if len(frames) == 0 {
+		return 0, fmt.Errorf("no frames extracted from %s", frameDir)
+	}
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		return 0, err
+	}
+
+	sem := make(chan struct{}, maxConcurrent)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var firstErr error
+	var done int
+
+	for _, frame := range frames {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return done, ctx.Err()
+		default:
+		}
+
+		frame := frame
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			frameInput := input
+			frameInput.ImagePath = frame
+			frameInput.OutputPath = filepath.Join(outDir, filepath.Base(frame))
+
+			if _, err := upscayl.Upscayl(frameInput); err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = fmt.Errorf("upscayling %s: %w", frame, err)
+				}
+				mu.Unlock()
+				return
+			}
+
+			mu.Lock()
+			done++
+			log.Printf("upscayled frame %d/%d", done, len(frames))
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	return done, firstErr
+}
+
+// reassembleVideo re-encodes the upscayled frames back into a video,
+// preserving the original audio track and container timing.
+func reassembleVideo(inputPath, frameOutDir, outputPath, fps, videoCodec string, crf int) error {
+	args := []string{
+		"-y",
+		"-framerate", fps,
+		"-i", filepath.Join(frameOutDir, "frame_%08d.png"),
+		"-i", inputPath,
+		"-map", "0:v",
+		"-map", "1:a?",
+		"-c:a", "copy",
+		"-c:v", videoCodec,
+		"-crf", strconv.Itoa(crf),
+		"-pix_fmt", "yuv420p",
+		outputPath,
+	}
+	cmd := exec.Command("ffmpeg", args...)
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+func isVideoFile(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".mp4", ".webm", ".mov", ".mkv", ".avi":
+		return true
+	}
+	return false
+}
+
+func getRunVideoCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "run-video",
+		Short: "Upscayl every frame of a video and reassemble it with the original audio",
+		Run: func(cmd *cobra.Command, args []string) {
+			inputPath, _ := cmd.Flags().GetString("input")
+			output, _ := cmd.Flags().GetString("output")
+			model, _ := cmd.Flags().GetString("model-name")
+			modelPath, _ := cmd.Flags().GetString("model-path")
+			fpsOut, _ := cmd.Flags().GetString("fps-out")
+			videoCodec, _ := cmd.Flags().GetString("video-codec")
+			crf, _ := cmd.Flags().GetInt("crf")
+			keepFrames, _ := cmd.Flags().GetBool("keep-frames")
+			maxConcurrentFrames, _ := cmd.Flags().GetInt("max-concurrent-frames")
+
+			if inputPath == "" {
+				log.Fatal("--input is required")
+			}
+			if !isVideoFile(inputPath) {
+				log.Fatal("--input does not look like a video file (expected mp4/webm/mov/mkv/avi)")
+			}
+
+			probe, err := probeVideo(inputPath)
+			if err != nil {
+				log.Fatal("error probing video: ", err.Error())
+			}
+			fps := fpsOut
+			if fps == "" {
+				fps = probe.FPS
+			}
+
+			scratchDir, err := os.MkdirTemp("", "upscayl-video-*")
+			if err != nil {
+				log.Fatal("error creating scratch directory: ", err.Error())
+			}
+			frameDir := filepath.Join(scratchDir, "frames")
+			frameOutDir := filepath.Join(scratchDir, "frames-out")
+
+			cleanup := func() {
+				if !keepFrames {
+					os.RemoveAll(scratchDir)
+				}
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			sigCh := make(chan os.Signal, 1)
+			signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+			go func() {
+				<-sigCh
+				log.Println("interrupted, cleaning up scratch frame directory...")
+				cancel()
+			}()
+
+			log.Println("extracting frames from", inputPath)
+			if err := extractFrames(ctx, inputPath, frameDir); err != nil {
+				cleanup()
+				log.Fatal("error extracting frames: ", err.Error())
+			}
+
+			log.Println("upscayling frames (max-concurrent-frames =", maxConcurrentFrames, ")")
+			_, err = upscaylFrames(ctx, frameDir, frameOutDir, maxConcurrentFrames, upscayl.Input{
+				Model:     model,
+				ModelPath: modelPath,
+			})
+			if err != nil {
+				cleanup()
+				log.Fatal("error upscayling frames: ", err.Error())
+			}
+
+			log.Println("reassembling video to", output)
+			if err := reassembleVideo(inputPath, frameOutDir, output, fps, videoCodec, crf); err != nil {
+				cleanup()
+				log.Fatal("error reassembling video: ", err.Error())
+			}
+
+			cleanup()
+			log.Println("output video at", output)
+		},
+	}
+	cmd.Flags().StringP("input", "i", "", "Input video path (mp4/webm/mov/mkv/avi)")
+	cmd.Flags().StringP("output", "o", "", "Output video path")
+	cmd.Flags().StringP("model-path", "m", "models", "Folder path to the pre-trained models")
+	cmd.Flags().StringP("model-name", "n", "realesrgan-x4plus", "Model name")
+	cmd.Flags().String("fps-out", "", "Output frame rate (default=same as input)")
+	cmd.Flags().String("video-codec", "libx264", "Video codec used to re-encode the upscayled frames")
+	cmd.Flags().Int("crf", 18, "Constant rate factor passed to the output video codec")
+	cmd.Flags().Bool("keep-frames", false, "Keep the scratch frame directory instead of deleting it on completion")
+	cmd.Flags().Int("max-concurrent-frames", 1, "Maximum number of frames being upscayled at once")
+	return cmd
+}